@@ -1,10 +1,10 @@
 package main
 
-
 import (
 	"crypto/rand"
+	"encoding/asn1"
+	"encoding/pem"
 	"errors"
-	"fmt"
 	"io"
 	"math/big"
 )
@@ -14,6 +14,19 @@ var one = big.NewInt(1)
 // ErrMessageTooLong 当所需加密信息长度大于公钥长度时，报错。
 var ErrMessageTooLong = errors.New("信息过长！请调整公钥长度！")
 
+// ErrInvalidPEMBlock 当PEM数据的块类型与期望的类型不符，或数据无法解析时，报错。
+var ErrInvalidPEMBlock = errors.New("PEM数据块类型不符或内容无法解析！")
+
+// ErrNotInvertible 当base与modulus不互素、无法求其乘法逆元时，报错。
+var ErrNotInvertible = errors.New("底数与模数不互素，无法求逆！")
+
+// ErrInvalidCiphertext 当解码得到的密文声明的Len不足以容纳其C值时，报错。
+var ErrInvalidCiphertext = errors.New("密文数据已损坏：声明的长度不足以容纳其携带的数值！")
+
+// blindBits 是expBlinded盲化因子的位宽：指数在做模幂前先加上该位宽内的随机倍数，
+// 以降低Exp内部对指数逐比特处理所造成的时间侧信道泄露。
+const blindBits = 128
+
 // GenerateKey 生成指定位数的公私钥。
 func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
 	// 生成素数p
@@ -35,11 +48,17 @@ func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
 	if err := <-errChan; err != nil {
 		return nil, err
 	}
+
+	return newPrivateKeyFromPQ(p, q), nil
+}
+
+// newPrivateKeyFromPQ 根据素数p、q推导出完整的私钥结构（包括公钥部分与CRT解密所需的缓存字段）。
+func newPrivateKeyFromPQ(p, q *big.Int) *PrivateKey {
 	n := new(big.Int).Mul(p, q)
 	pp := new(big.Int).Mul(p, p)
 	qq := new(big.Int).Mul(q, q)
 
-	return &PrivateKey{
+	priv := &PrivateKey{
 		PublicKey: PublicKey{
 			N:        n,
 			NSquared: new(big.Int).Mul(n, n),
@@ -51,8 +70,21 @@ func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
 		q:         q,
 		qq:        qq,
 		qminusone: new(big.Int).Sub(q, one),
-	}, nil
+	}
+	precompute(priv)
+	return priv
+}
+
+// precompute 缓存CRT解密所需的h_p、h_q：h_p = L_p(g^(p-1) mod p^2)^(-1) mod p，h_q同理，
+// 使Decrypt只需对p、q各做一次half-size模幂，而不必在N^2规模上做一次full-size模幂。
+func precompute(priv *PrivateKey) {
+	gp := new(big.Int).Mod(priv.PublicKey.G, priv.pp)
+	lp := L(new(big.Int).Exp(gp, priv.pminusone, priv.pp), priv.p)
+	priv.hp = new(big.Int).ModInverse(lp, priv.p)
 
+	gq := new(big.Int).Mod(priv.PublicKey.G, priv.qq)
+	lq := L(new(big.Int).Exp(gq, priv.qminusone, priv.qq), priv.q)
+	priv.hq = new(big.Int).ModInverse(lq, priv.q)
 }
 
 // PrivateKey 私钥
@@ -64,6 +96,8 @@ type PrivateKey struct {
 	q         *big.Int
 	qq        *big.Int
 	qminusone *big.Int
+	hp        *big.Int // CRT解密缓存：L_p(g^(p-1) mod p^2)^(-1) mod p
+	hq        *big.Int // CRT解密缓存：L_q(g^(q-1) mod q^2)^(-1) mod q
 	Lambda    *big.Int
 }
 
@@ -74,13 +108,22 @@ type PublicKey struct {
 	NSquared *big.Int
 }
 
+// Ciphertext 表示一个Paillier密文，Len为该密文所在模数的字节宽度（经典Paillier为N^2，
+// Damgård–Jurik为N^(S+1)），
+// 序列化时按该宽度对C做定长补零编码，避免C的前导零字节在还原时被误丢弃
+// （这在同态相加结果恰好以0x00开头时会导致数值错误）。
+type Ciphertext struct {
+	C   *big.Int
+	Len int
+}
+
 // L L(x)=(x-1)/n
 func L(x, n *big.Int) *big.Int {
 	return new(big.Int).Div(new(big.Int).Sub(x, one), n)
 }
 
 // Encrypt 加密。
-func Encrypt(pubKey *PublicKey, plainText []byte) ([]byte, *big.Int, error) {
+func Encrypt(pubKey *PublicKey, plainText []byte) (*Ciphertext, *big.Int, error) {
 	r, err := rand.Int(rand.Reader, pubKey.N)
 	if err != nil {
 		return nil, nil, err
@@ -100,66 +143,236 @@ func Encrypt(pubKey *PublicKey, plainText []byte) ([]byte, *big.Int, error) {
 		pubKey.NSquared,
 	)
 
-	return c.Bytes(), r, nil
+	return &Ciphertext{C: c, Len: byteLen(pubKey.NSquared)}, r, nil
 }
 
-// Decrypt 解密。
-func Decrypt(privKey *PrivateKey, cipherText []byte) ([]byte, error) {
-	c := new(big.Int).SetBytes(cipherText)
-	if privKey.NSquared.Cmp(c) < 1 {
+// Decrypt 解密，借助CRT将一次N^2规模的模幂拆成p、q上各一次half-size模幂再合并，约有4倍加速。
+func Decrypt(privKey *PrivateKey, cipher *Ciphertext) ([]byte, error) {
+	if privKey.NSquared.Cmp(cipher.C) < 1 {
 		return nil, ErrMessageTooLong
 	}
-	mu := new(big.Int).ModInverse(privKey.Lambda, privKey.N)
-	m := new(big.Int).Mod(new(big.Int).Mul(L(new(big.Int).Exp(c, privKey.Lambda, privKey.NSquared), privKey.N), mu), privKey.N)
+
+	cp := new(big.Int).Mod(cipher.C, privKey.pp)
+	orderP := new(big.Int).Mul(privKey.p, privKey.pminusone) // |Z_{p^2}*| = p(p-1)
+	expP, err := expBlinded(cp, privKey.pminusone, orderP, privKey.pp)
+	if err != nil {
+		return nil, err
+	}
+	mp := new(big.Int).Mod(new(big.Int).Mul(L(expP, privKey.p), privKey.hp), privKey.p)
+
+	cq := new(big.Int).Mod(cipher.C, privKey.qq)
+	orderQ := new(big.Int).Mul(privKey.q, privKey.qminusone) // |Z_{q^2}*| = q(q-1)
+	expQ, err := expBlinded(cq, privKey.qminusone, orderQ, privKey.qq)
+	if err != nil {
+		return nil, err
+	}
+	mq := new(big.Int).Mod(new(big.Int).Mul(L(expQ, privKey.q), privKey.hq), privKey.q)
+
+	m := combineCRT(mp, privKey.p, mq, privKey.q, privKey.N)
 	return m.Bytes(), nil
 }
 
-// AddCipher 将两个密文相乘，以达到明文相加的目的。
-func AddCipher(pubKey *PublicKey, cipher1, cipher2 []byte) []byte {
-	x := new(big.Int).SetBytes(cipher1)
-	y := new(big.Int).SetBytes(cipher2)
-	// x * y mod n^2
-	return new(big.Int).Mod(new(big.Int).Mul(x, y), pubKey.NSquared).Bytes()
+// combineCRT 由mp≡m (mod p)、mq≡m (mod q)（p、q互素，n=p*q）按中国剩余定理合并出m mod n。
+func combineCRT(mp, p, mq, q, n *big.Int) *big.Int {
+	pInvModQ := new(big.Int).ModInverse(p, q)
+	h := new(big.Int).Sub(mq, mp)
+	h.Mod(h, q)
+	h.Mul(h, pInvModQ)
+	h.Mod(h, q)
+
+	m := new(big.Int).Mul(h, p)
+	m.Add(m, mp)
+	m.Mod(m, n)
+	return m
 }
 
-func main() {
-	// 生成一个4096位私钥
-	privKey, err := GenerateKey(rand.Reader, 4096)
+// expBlinded 计算base^exponent mod modulus，计算前先给exponent叠加order的随机倍数做指数盲化
+// （order须为base所在群的阶，或其倍数，以保证加法不改变运算结果），使实际参与模幂的指数
+// 不再直接暴露原始exponent的取值与位宽，缓解针对该模幂的时间侧信道。
+func expBlinded(base, exponent, order, modulus *big.Int) (*big.Int, error) {
+	bound := new(big.Int).Lsh(one, uint(blindBits))
+	r, err := rand.Int(rand.Reader, bound)
 	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	privKey.Lambda = new(big.Int).Mul(privKey.pminusone, privKey.qminusone)
-	// 加密明文1
-	fmt.Print("请输入第一个明文：")
-	var Plaintext1 big.Int
-	fmt.Scan(&Plaintext1)
-	Cipher1, _, err := Encrypt(&privKey.PublicKey, Plaintext1.Bytes())
+		return nil, err
+	}
+	blinded := new(big.Int).Mul(r, order)
+	blinded.Add(blinded, exponent)
+	return new(big.Int).Exp(base, blinded, modulus), nil
+}
+
+// expBlindedUnknownOrder 计算base^exponent mod modulus，在调用方不知道base所在群的阶时
+// 仍对secret-dependent的exponent做盲化：取与exponent位宽相当的随机数r，先算出
+// base^(exponent+r)与base^r，两者相除（乘以后者的逆元）即得base^exponent，恒等成立而无需
+// 知道任何阶——(t, n)门限方案中单独持有一份份额的一方并不掌握N的分解，因而无法像expBlinded
+// 那样给出Z_{N^2}*的真实阶（给出该阶等价于给出N的分解，会破坏门限方案的安全性）。
+func expBlindedUnknownOrder(base, exponent, modulus *big.Int) (*big.Int, error) {
+	bound := new(big.Int).Lsh(one, uint(exponent.BitLen()+blindBits))
+	r, err := rand.Int(rand.Reader, bound)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
+	padded := new(big.Int).Add(exponent, r)
+	numerator := new(big.Int).Exp(base, padded, modulus)
+	denom := new(big.Int).Exp(base, r, modulus)
+	denomInv := new(big.Int).ModInverse(denom, modulus)
+	if denomInv == nil {
+		return nil, ErrNotInvertible
+	}
+	result := new(big.Int).Mul(numerator, denomInv)
+	result.Mod(result, modulus)
+	return result, nil
+}
 
-	// 加密明文2
-	fmt.Print("请输入第二个明文：")
-	var plaintext2 big.Int
-	fmt.Scan(&plaintext2)
-	Cipher2, _, err := Encrypt(&privKey.PublicKey, plaintext2.Bytes())
+// AddCipher 将两个密文相乘，以达到明文相加的目的。
+func AddCipher(pubKey *PublicKey, cipher1, cipher2 *Ciphertext) *Ciphertext {
+	c := new(big.Int).Mod(new(big.Int).Mul(cipher1.C, cipher2.C), pubKey.NSquared)
+	return &Ciphertext{C: c, Len: byteLen(pubKey.NSquared)}
+}
+
+// byteLen 返回x的定长字节宽度，即表示x所需的最少字节数。
+func byteLen(x *big.Int) int {
+	return (x.BitLen() + 7) / 8
+}
+
+// ciphertextASN1 是Ciphertext的ASN.1 DER编码形式：Len为定长宽度，C为按该宽度补零的密文字节串。
+type ciphertextASN1 struct {
+	Len int
+	C   []byte
+}
+
+// MarshalBinary 将密文编码为ASN.1 DER字节串，C按Len做定长补零，保证前导零字节不丢失。
+func (c *Ciphertext) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, c.Len)
+	c.C.FillBytes(buf)
+	return asn1.Marshal(ciphertextASN1{Len: c.Len, C: buf})
+}
+
+// UnmarshalBinary 从MarshalBinary产生的ASN.1 DER字节串中还原密文。校验声明的Len
+// 足以容纳解码出的C，避免被篡改或损坏的数据通过解码后，在日后MarshalBinary时才触发panic。
+func (c *Ciphertext) UnmarshalBinary(data []byte) error {
+	var raw ciphertextASN1
+	if _, err := asn1.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Len < 0 || len(raw.C) > raw.Len {
+		return ErrInvalidCiphertext
+	}
+	c.Len = raw.Len
+	c.C = new(big.Int).SetBytes(raw.C)
+	return nil
+}
+
+// MarshalPEM 将密文编码为携带"PAILLIER CIPHERTEXT"块类型的PEM文本，便于持久化或通过网络传输。
+func (c *Ciphertext) MarshalPEM() ([]byte, error) {
+	der, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PAILLIER CIPHERTEXT", Bytes: der}), nil
+}
+
+// ParseCiphertextPEM 解析MarshalPEM产生的PEM文本，还原出密文。
+func ParseCiphertextPEM(data []byte) (*Ciphertext, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PAILLIER CIPHERTEXT" {
+		return nil, ErrInvalidPEMBlock
+	}
+	c := new(Ciphertext)
+	if err := c.UnmarshalBinary(block.Bytes); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// publicKeyASN1 是PublicKey的ASN.1 DER编码形式；G与NSquared均可由N推出，故只编码N。
+type publicKeyASN1 struct {
+	N *big.Int
+}
+
+// MarshalBinary 将公钥编码为ASN.1 DER字节串。
+func (pub *PublicKey) MarshalBinary() ([]byte, error) {
+	return asn1.Marshal(publicKeyASN1{N: pub.N})
+}
+
+// UnmarshalBinary 从MarshalBinary产生的ASN.1 DER字节串中还原公钥。
+func (pub *PublicKey) UnmarshalBinary(data []byte) error {
+	var raw publicKeyASN1
+	if _, err := asn1.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	pub.N = raw.N
+	pub.NSquared = new(big.Int).Mul(raw.N, raw.N)
+	pub.G = new(big.Int).Add(raw.N, one)
+	return nil
+}
+
+// MarshalPEM 将公钥编码为携带"PAILLIER PUBLIC KEY"块类型的PEM文本。
+func (pub *PublicKey) MarshalPEM() ([]byte, error) {
+	der, err := pub.MarshalBinary()
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PAILLIER PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM 解析MarshalPEM产生的PEM文本，还原出公钥。
+func ParsePublicKeyPEM(data []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PAILLIER PUBLIC KEY" {
+		return nil, ErrInvalidPEMBlock
+	}
+	pub := new(PublicKey)
+	if err := pub.UnmarshalBinary(block.Bytes); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// privateKeyASN1 是PrivateKey的ASN.1 DER编码形式；p、q、Lambda之外的字段均可推出。
+type privateKeyASN1 struct {
+	P      *big.Int
+	Q      *big.Int
+	Lambda *big.Int
+}
+
+// MarshalBinary 将私钥编码为ASN.1 DER字节串。
+func (priv *PrivateKey) MarshalBinary() ([]byte, error) {
+	lambda := priv.Lambda
+	if lambda == nil {
+		lambda = new(big.Int).Mul(priv.pminusone, priv.qminusone)
 	}
+	return asn1.Marshal(privateKeyASN1{P: priv.p, Q: priv.q, Lambda: lambda})
+}
 
-	fmt.Println("对第一个明文加密后得到密文：", new(big.Int).SetBytes(Cipher1))
-	fmt.Println("对第二个明文加密后得到密文：", new(big.Int).SetBytes(Cipher2))
+// UnmarshalBinary 从MarshalBinary产生的ASN.1 DER字节串中还原私钥。
+func (priv *PrivateKey) UnmarshalBinary(data []byte) error {
+	var raw privateKeyASN1
+	if _, err := asn1.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*priv = *newPrivateKeyFromPQ(raw.P, raw.Q)
+	priv.Lambda = raw.Lambda
+	return nil
+}
 
-	// 将明文1与明文2相加。
-	EncryptedPlusCipher1Cipher2 := AddCipher(&privKey.PublicKey, Cipher1, Cipher2)
-	fmt.Println("两密文相乘得到：", new(big.Int).SetBytes(EncryptedPlusCipher1Cipher2))
-	DecyptedPlusCipher1Cipher2, err := Decrypt(privKey, EncryptedPlusCipher1Cipher2)
+// MarshalPEM 将私钥编码为携带"PAILLIER PRIVATE KEY"块类型的PEM文本。
+func (priv *PrivateKey) MarshalPEM() ([]byte, error) {
+	der, err := priv.MarshalBinary()
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PAILLIER PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM 解析MarshalPEM产生的PEM文本，还原出私钥。
+func ParsePrivateKeyPEM(data []byte) (*PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PAILLIER PRIVATE KEY" {
+		return nil, ErrInvalidPEMBlock
+	}
+	priv := new(PrivateKey)
+	if err := priv.UnmarshalBinary(block.Bytes); err != nil {
+		return nil, err
 	}
-	fmt.Println("密文相乘后解密得到的明文为：", new(big.Int).SetBytes(DecyptedPlusCipher1Cipher2))
+	return priv, nil
 }
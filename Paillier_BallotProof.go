@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidBallot 当选票中某项取值不为0或1时，由ProveBallot返回；总票数不为1时ProveBallot
+// 不返回该错误，但其生成的证明无法通过VerifyBallot校验。
+var ErrInvalidBallot = errors.New("选票不合法！请确认每位候选人得票为0或1，且总票数为1！")
+
+// bitProof 是“密文c加密的明文m属于{0,1}”的CDS式OR证明中的一个分支。
+type bitProof struct {
+	A *big.Int // 承诺
+	E *big.Int // 该分支的挑战
+	Z *big.Int // 该分支的响应
+}
+
+// BitProof 证明某一密文加密的明文属于{0,1}。两个分支中只有一个是真实证明，
+// 另一个由模拟器构造，两分支挑战之和e0+e1 (mod n^2)须等于Fiat-Shamir得到的整体挑战e。
+type BitProof struct {
+	Zero bitProof // m=0分支
+	One  bitProof // m=1分支
+}
+
+// SumProof 证明Π c_i解密后为1，即∑m_i=1，做法是证明 C·(1+n)^{-1} 是一个n次剩余（即加密了0）。
+type SumProof struct {
+	A *big.Int
+	E *big.Int
+	Z *big.Int
+}
+
+// BallotProof 证明一组密文构成一张合法选票：每项取值为0或1，且恰好一项为1。
+type BallotProof struct {
+	Bits []*BitProof
+	Sum  *SumProof
+}
+
+// hashBallotChallenge 按Fiat-Shamir变换对公钥、密文与承诺做SHA-256摘要，得到整体挑战。
+func hashBallotChallenge(pubKey *PublicKey, c *big.Int, as ...*big.Int) *big.Int {
+	h := sha256.New()
+	h.Write(pubKey.N.Bytes())
+	h.Write(c.Bytes())
+	for _, a := range as {
+		h.Write(a.Bytes())
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// proveBit 构造密文c=Enc(m,r)对应明文m∈{0,1}的OR证明。
+func proveBit(pubKey *PublicKey, c, r *big.Int, m int) (*BitProof, error) {
+	if m != 0 && m != 1 {
+		return nil, ErrInvalidBallot
+	}
+
+	// 真实分支：随机rho，承诺a=rho^n mod n^2
+	rho, err := rand.Int(rand.Reader, pubKey.N)
+	if err != nil {
+		return nil, err
+	}
+	aReal := new(big.Int).Exp(rho, pubKey.N, pubKey.NSquared)
+
+	// 伪造分支：先选好响应z'与挑战e'，反推出承诺a' = z'^n * base'^(-e') mod n^2
+	zFake, err := rand.Int(rand.Reader, pubKey.N)
+	if err != nil {
+		return nil, err
+	}
+	eFake, err := rand.Int(rand.Reader, pubKey.NSquared)
+	if err != nil {
+		return nil, err
+	}
+
+	var fakeBase *big.Int
+	if m == 0 {
+		gInv := new(big.Int).ModInverse(pubKey.G, pubKey.NSquared)
+		fakeBase = new(big.Int).Mod(new(big.Int).Mul(c, gInv), pubKey.NSquared)
+	} else {
+		fakeBase = new(big.Int).Set(c)
+	}
+	aFake := new(big.Int).Exp(zFake, pubKey.N, pubKey.NSquared)
+	baseInvE := new(big.Int).Exp(fakeBase, new(big.Int).Neg(eFake), pubKey.NSquared)
+	aFake.Mul(aFake, baseInvE)
+	aFake.Mod(aFake, pubKey.NSquared)
+
+	var a0, a1 *big.Int
+	if m == 0 {
+		a0, a1 = aReal, aFake
+	} else {
+		a0, a1 = aFake, aReal
+	}
+
+	e := hashBallotChallenge(pubKey, c, a0, a1)
+	eReal := new(big.Int).Sub(e, eFake)
+	eReal.Mod(eReal, pubKey.NSquared)
+
+	zReal := new(big.Int).Exp(r, eReal, pubKey.NSquared)
+	zReal.Mul(zReal, rho)
+	zReal.Mod(zReal, pubKey.NSquared)
+
+	var zeroBranch, oneBranch bitProof
+	if m == 0 {
+		zeroBranch = bitProof{A: a0, E: eReal, Z: zReal}
+		oneBranch = bitProof{A: a1, E: eFake, Z: zFake}
+	} else {
+		zeroBranch = bitProof{A: a0, E: eFake, Z: zFake}
+		oneBranch = bitProof{A: a1, E: eReal, Z: zReal}
+	}
+	return &BitProof{Zero: zeroBranch, One: oneBranch}, nil
+}
+
+// verifyBit 校验proveBit生成的{0,1}-OR证明。
+func verifyBit(pubKey *PublicKey, c *big.Int, proof *BitProof) bool {
+	e := hashBallotChallenge(pubKey, c, proof.Zero.A, proof.One.A)
+	eSum := new(big.Int).Add(proof.Zero.E, proof.One.E)
+	eSum.Mod(eSum, pubKey.NSquared)
+	if eSum.Cmp(new(big.Int).Mod(e, pubKey.NSquared)) != 0 {
+		return false
+	}
+
+	gInv := new(big.Int).ModInverse(pubKey.G, pubKey.NSquared)
+	cOverG := new(big.Int).Mod(new(big.Int).Mul(c, gInv), pubKey.NSquared)
+
+	if !checkBitBranch(pubKey, c, &proof.Zero) {
+		return false
+	}
+	if !checkBitBranch(pubKey, cOverG, &proof.One) {
+		return false
+	}
+	return true
+}
+
+// checkBitBranch 校验单个分支：z^n =? a * base^e mod n^2。
+func checkBitBranch(pubKey *PublicKey, base *big.Int, branch *bitProof) bool {
+	left := new(big.Int).Exp(branch.Z, pubKey.N, pubKey.NSquared)
+	right := new(big.Int).Exp(base, branch.E, pubKey.NSquared)
+	right.Mul(right, branch.A)
+	right.Mod(right, pubKey.NSquared)
+	return left.Cmp(right) == 0
+}
+
+// proveSum 证明密文C加密了0，即C=r^n mod n^2，用于证明选票各项之和恰为1。
+func proveSum(pubKey *PublicKey, capitalC, r *big.Int) (*SumProof, error) {
+	rho, err := rand.Int(rand.Reader, pubKey.N)
+	if err != nil {
+		return nil, err
+	}
+	a := new(big.Int).Exp(rho, pubKey.N, pubKey.NSquared)
+	e := hashBallotChallenge(pubKey, capitalC, a)
+	z := new(big.Int).Exp(r, e, pubKey.NSquared)
+	z.Mul(z, rho)
+	z.Mod(z, pubKey.NSquared)
+	return &SumProof{A: a, E: e, Z: z}, nil
+}
+
+// verifySum 校验proveSum生成的证明。
+func verifySum(pubKey *PublicKey, capitalC *big.Int, proof *SumProof) bool {
+	e := hashBallotChallenge(pubKey, capitalC, proof.A)
+	if e.Cmp(proof.E) != 0 {
+		return false
+	}
+	left := new(big.Int).Exp(proof.Z, pubKey.N, pubKey.NSquared)
+	right := new(big.Int).Exp(capitalC, proof.E, pubKey.NSquared)
+	right.Mul(right, proof.A)
+	right.Mod(right, pubKey.NSquared)
+	return left.Cmp(right) == 0
+}
+
+// provePackedVote 证明packed（以packedR为随机数加密）与ciphertexts（以randomizers为随机数
+// 逐项加密）按bitsPerCandidate打包后携带同一明文：两者相除应得到一个加密0的密文，其隐含的
+// 随机数diffR = packedR * (Π randomizers[i]^(B^i))^(-1) mod n 可直接算出（因为PackCiphertexts
+// 对经典Paillier密文做同态幂运算时，随机数也按同样的权重B^i相应幂化），故复用proveSum即可构造证明，
+// 无需引入新的零知识证明原语。
+func provePackedVote(pubKey *PublicKey, ciphertexts []*Ciphertext, randomizers []*big.Int, bitsPerCandidate int, packed *Ciphertext, packedR *big.Int) (*SumProof, error) {
+	base := new(big.Int).Lsh(one, uint(bitsPerCandidate))
+	weight := big.NewInt(1)
+	combinedR := big.NewInt(1)
+	for _, r := range randomizers {
+		combinedR.Mul(combinedR, new(big.Int).Exp(r, weight, pubKey.N))
+		combinedR.Mod(combinedR, pubKey.N)
+		weight.Mul(weight, base)
+	}
+	combinedRInv := new(big.Int).ModInverse(combinedR, pubKey.N)
+	if combinedRInv == nil {
+		return nil, ErrNotInvertible
+	}
+	diffR := new(big.Int).Mod(new(big.Int).Mul(packedR, combinedRInv), pubKey.N)
+
+	expected := PackCiphertexts(pubKey, ciphertexts, bitsPerCandidate)
+	expectedInv := new(big.Int).ModInverse(expected.C, pubKey.NSquared)
+	if expectedInv == nil {
+		return nil, ErrNotInvertible
+	}
+	diff := new(big.Int).Mod(new(big.Int).Mul(packed.C, expectedInv), pubKey.NSquared)
+
+	return proveSum(pubKey, diff, diffR)
+}
+
+// ProveBallot 为一张选票的全部密文生成零知识证明：每项密文加密的明文为0或1，且恰好一项为1。
+// votes与randomizers须与ciphertexts一一对应，randomizers为Encrypt返回的r。
+func ProveBallot(pubKey *PublicKey, votes []int, randomizers []*big.Int) (*BallotProof, error) {
+	bits := make([]*BitProof, len(votes))
+	capitalR := big.NewInt(1)
+	for i, v := range votes {
+		if v != 0 && v != 1 {
+			return nil, ErrInvalidBallot
+		}
+		m := big.NewInt(int64(v))
+		c := new(big.Int).Mod(
+			new(big.Int).Mul(
+				new(big.Int).Mod(new(big.Int).Add(one, new(big.Int).Mul(m, pubKey.N)), pubKey.NSquared),
+				new(big.Int).Exp(randomizers[i], pubKey.N, pubKey.NSquared),
+			),
+			pubKey.NSquared,
+		)
+		proof, err := proveBit(pubKey, c, randomizers[i], v)
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = proof
+		capitalR.Mul(capitalR, randomizers[i])
+		capitalR.Mod(capitalR, pubKey.N)
+	}
+
+	// C = Π c_i，总票数之和的密文；其明文应恰为1，故C*(1+n)^{-1}应加密0。
+	capitalM := big.NewInt(0)
+	for _, v := range votes {
+		capitalM.Add(capitalM, big.NewInt(int64(v)))
+	}
+	gInv := new(big.Int).ModInverse(pubKey.G, pubKey.NSquared)
+	capitalC := new(big.Int).Exp(new(big.Int).Add(one, new(big.Int).Mul(capitalM, pubKey.N)), one, pubKey.NSquared)
+	capitalC.Mod(new(big.Int).Mul(capitalC, new(big.Int).Exp(capitalR, pubKey.N, pubKey.NSquared)), pubKey.NSquared)
+	capitalCOverG := new(big.Int).Mod(new(big.Int).Mul(capitalC, gInv), pubKey.NSquared)
+
+	sum, err := proveSum(pubKey, capitalCOverG, capitalR)
+	if err != nil {
+		return nil, err
+	}
+	return &BallotProof{Bits: bits, Sum: sum}, nil
+}
+
+// VerifyBallot 校验ProveBallot生成的证明：逐项确认密文加密0或1，并确认全部密文之积减去偏移后加密了0（即总票数为1）。
+func VerifyBallot(pubKey *PublicKey, ciphertexts []*Ciphertext, proof *BallotProof) bool {
+	if len(ciphertexts) != len(proof.Bits) {
+		return false
+	}
+	capitalC := big.NewInt(1)
+	for i, ct := range ciphertexts {
+		if !verifyBit(pubKey, ct.C, proof.Bits[i]) {
+			return false
+		}
+		capitalC.Mul(capitalC, ct.C)
+		capitalC.Mod(capitalC, pubKey.NSquared)
+	}
+	gInv := new(big.Int).ModInverse(pubKey.G, pubKey.NSquared)
+	capitalCOverG := new(big.Int).Mod(new(big.Int).Mul(capitalC, gInv), pubKey.NSquared)
+	return verifySum(pubKey, capitalCOverG, proof.Sum)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// testBallotKey 生成一把用于测试的小位数密钥，仅用于加速测试，不代表生产环境的安全参数。
+func testBallotKey(t *testing.T) *PrivateKey {
+	t.Helper()
+	priv, err := GenerateKey(rand.Reader, 256)
+	if err != nil {
+		t.Fatalf("GenerateKey失败: %v", err)
+	}
+	return priv
+}
+
+// encryptVotes依次加密votes中的每一项，返回密文及其加密随机数，供ProveBallot使用。
+func encryptVotes(t *testing.T, pubKey *PublicKey, votes []int) ([]*Ciphertext, []*big.Int) {
+	t.Helper()
+	ciphertexts := make([]*Ciphertext, len(votes))
+	randomizers := make([]*big.Int, len(votes))
+	for i, v := range votes {
+		ct, r, err := Encrypt(pubKey, big.NewInt(int64(v)).Bytes())
+		if err != nil {
+			t.Fatalf("Encrypt失败: %v", err)
+		}
+		ciphertexts[i] = ct
+		randomizers[i] = r
+	}
+	return ciphertexts, randomizers
+}
+
+func TestProveVerifyBallot_ValidBallotRoundTrips(t *testing.T) {
+	priv := testBallotKey(t)
+	votes := []int{0, 1, 0}
+	ciphertexts, randomizers := encryptVotes(t, &priv.PublicKey, votes)
+
+	proof, err := ProveBallot(&priv.PublicKey, votes, randomizers)
+	if err != nil {
+		t.Fatalf("ProveBallot失败: %v", err)
+	}
+	if !VerifyBallot(&priv.PublicKey, ciphertexts, proof) {
+		t.Fatal("合法选票未能通过VerifyBallot校验")
+	}
+}
+
+func TestProveBallot_RejectsOutOfRangeVote(t *testing.T) {
+	priv := testBallotKey(t)
+	votes := []int{2, 0}
+	_, randomizers := encryptVotes(t, &priv.PublicKey, votes)
+
+	if _, err := ProveBallot(&priv.PublicKey, votes, randomizers); err != ErrInvalidBallot {
+		t.Fatalf("votes含有非0/1取值时应返回ErrInvalidBallot，实际返回: %v", err)
+	}
+}
+
+func TestVerifyBallot_RejectsDoubleVote(t *testing.T) {
+	priv := testBallotKey(t)
+	// 每项均为合法的0/1取值，但总票数为2，恶意投票者企图为两名候选人各投1票。
+	votes := []int{1, 1, 0}
+	ciphertexts, randomizers := encryptVotes(t, &priv.PublicKey, votes)
+
+	proof, err := ProveBallot(&priv.PublicKey, votes, randomizers)
+	if err != nil {
+		t.Fatalf("ProveBallot失败: %v", err)
+	}
+	if VerifyBallot(&priv.PublicKey, ciphertexts, proof) {
+		t.Fatal("总票数不为1的选票本应被VerifyBallot拒绝")
+	}
+}
+
+func TestVerifyBallot_RejectsForgedCiphertext(t *testing.T) {
+	priv := testBallotKey(t)
+	votes := []int{0, 1, 0}
+	ciphertexts, randomizers := encryptVotes(t, &priv.PublicKey, votes)
+
+	proof, err := ProveBallot(&priv.PublicKey, votes, randomizers)
+	if err != nil {
+		t.Fatalf("ProveBallot失败: %v", err)
+	}
+
+	// 攻击者在不知道对应随机数的情况下，篡改其中一项密文（如试图把自己的0票换成1票）。
+	forged := append([]*Ciphertext(nil), ciphertexts...)
+	tampered, _, err := Encrypt(&priv.PublicKey, big.NewInt(1).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+	forged[0] = tampered
+
+	if VerifyBallot(&priv.PublicKey, forged, proof) {
+		t.Fatal("被篡改且未重新生成证明的密文本应被VerifyBallot拒绝")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// DJPublicKey 是Damgård–Jurik方案下的公钥：在经典Paillier的基础上引入参数S，
+// 将明文空间由Z_n扩展为Z_{N^S}，密文则落在Z_{N^(S+1)}中；S=1时即退化为经典Paillier。
+type DJPublicKey struct {
+	N   *big.Int
+	S   int
+	NS  *big.Int // N^S，明文空间的模数
+	NS1 *big.Int // N^(S+1)，密文所在群的模数
+}
+
+// DJPrivateKey Damgård–Jurik私钥。
+type DJPrivateKey struct {
+	DJPublicKey
+	Lambda *big.Int
+}
+
+// GenerateKeyDJ 生成(bits, s)参数下的Damgård–Jurik公私钥，明文空间为Z_{N^S}。
+func GenerateKeyDJ(random io.Reader, bits, s int) (*DJPrivateKey, error) {
+	if s < 1 {
+		return nil, errors.New("s必须至少为1")
+	}
+
+	// 生成素数p
+	var p *big.Int
+	var errChan = make(chan error, 1)
+	go func() {
+		var err error
+		p, err = rand.Prime(random, bits/2)
+		errChan <- err
+	}()
+
+	// 生成素数q
+	q, err := rand.Prime(random, bits/2)
+	if err != nil {
+		return nil, err
+	}
+
+	// 等待素数p生成完成
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	n := new(big.Int).Mul(p, q)
+	lambda := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+
+	ns := big.NewInt(1)
+	for i := 0; i < s; i++ {
+		ns.Mul(ns, n)
+	}
+	ns1 := new(big.Int).Mul(ns, n)
+
+	return &DJPrivateKey{
+		DJPublicKey: DJPublicKey{N: n, S: s, NS: ns, NS1: ns1},
+		Lambda:      lambda,
+	}, nil
+}
+
+// EncryptDJ 按Damgård–Jurik方案加密，c = (1+n)^m * r^(n^s) mod n^(s+1)。
+func EncryptDJ(pubKey *DJPublicKey, plainText []byte) (*Ciphertext, *big.Int, error) {
+	r, err := rand.Int(rand.Reader, pubKey.N)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := new(big.Int).SetBytes(plainText)
+	if pubKey.NS.Cmp(m) < 1 { // n^s <= m
+		return nil, nil, ErrMessageTooLong
+	}
+
+	g := new(big.Int).Add(one, pubKey.N) // g = 1 + n
+	c := new(big.Int).Exp(g, m, pubKey.NS1)
+	c.Mul(c, new(big.Int).Exp(r, pubKey.NS, pubKey.NS1))
+	c.Mod(c, pubKey.NS1)
+
+	return &Ciphertext{C: c, Len: byteLen(pubKey.NS1)}, r, nil
+}
+
+// DecryptDJ 按Damgård–Jurik方案解密：先算a=c^λ mod n^(s+1)，
+// 再用recoverDJPlaintext递归恢复a中携带的m*λ mod n^s，最后乘以λ^(-1)得到m。
+func DecryptDJ(privKey *DJPrivateKey, cipher *Ciphertext) ([]byte, error) {
+	if privKey.NS1.Cmp(cipher.C) < 1 {
+		return nil, ErrMessageTooLong
+	}
+
+	// Z_{n^(s+1)}*中任一元素的阶都整除n^s*λ(n)，Lambda=(p-1)(q-1)是λ(n)的倍数，故以n^s*Lambda为阶的倍数做指数盲化是安全的。
+	order := new(big.Int).Mul(privKey.NS, privKey.Lambda)
+	a, err := expBlinded(cipher.C, privKey.Lambda, order, privKey.NS1)
+	if err != nil {
+		return nil, err
+	}
+	mLambda := recoverDJPlaintext(a, privKey.N, privKey.S)
+
+	lambdaInv := new(big.Int).ModInverse(privKey.Lambda, privKey.NS)
+	m := new(big.Int).Mod(new(big.Int).Mul(mLambda, lambdaInv), privKey.NS)
+	return m.Bytes(), nil
+}
+
+// recoverDJPlaintext 从a≡(1+n)^m (mod n^(s+1))中按n进制逐位恢复m（m_1为最低位，m_s为最高位）。
+// 记M_{j-1}为已恢复的低j-1位（即m mod n^(j-1)），由(1+n)^(M_{j-1}+m_j*n^(j-1)) mod n^(j+1)
+// = (1+n)^M_{j-1} + m_j*n^j (mod n^(j+1))可知：
+// 将a与n^(j+1)取模后减去(1+n)^M_{j-1} mod n^(j+1)，再除以n^j即得第j位m_j，
+// 从而避免对m做整体离散对数求解。
+func recoverDJPlaintext(a, n *big.Int, s int) *big.Int {
+	nPow := make([]*big.Int, s+2)
+	nPow[0] = big.NewInt(1)
+	for i := 1; i <= s+1; i++ {
+		nPow[i] = new(big.Int).Mul(nPow[i-1], n)
+	}
+
+	g := new(big.Int).Add(one, n)
+	known := big.NewInt(0) // M_{j-1} = m mod n^(j-1)
+	for j := 1; j <= s; j++ {
+		aj := new(big.Int).Mod(a, nPow[j+1])
+		bj := new(big.Int).Exp(g, known, nPow[j+1])
+
+		diff := new(big.Int).Sub(aj, bj)
+		diff.Mod(diff, nPow[j+1])
+
+		digit := new(big.Int).Div(diff, nPow[j])
+		known.Add(known, new(big.Int).Mul(digit, nPow[j-1]))
+	}
+	return known
+}
+
+// AddCipherDJ 将两个Damgård–Jurik密文相乘，以达到明文相加的目的。
+func AddCipherDJ(pubKey *DJPublicKey, cipher1, cipher2 *Ciphertext) *Ciphertext {
+	c := new(big.Int).Mod(new(big.Int).Mul(cipher1.C, cipher2.C), pubKey.NS1)
+	return &Ciphertext{C: c, Len: byteLen(pubKey.NS1)}
+}
+
+// PackVotes 将k位候选人的票数打包为单个大整数m = Σ votes[i]*B^i（B = 2^bitsPerCandidate），
+// 使一张选票整体只需一次DJ加密，要求每位候选人的票数不超过B-1且互不溢出到相邻槽位。
+func PackVotes(votes []int, bitsPerCandidate int) *big.Int {
+	base := new(big.Int).Lsh(one, uint(bitsPerCandidate))
+	weight := big.NewInt(1)
+	m := big.NewInt(0)
+	for _, v := range votes {
+		m.Add(m, new(big.Int).Mul(big.NewInt(int64(v)), weight))
+		weight.Mul(weight, base)
+	}
+	return m
+}
+
+// PackCiphertexts 在密文侧重现PackVotes的打包：对每项经典Paillier密文按其槽位权重B^i
+// （B=2^bitsPerCandidate）做同态幂运算后相乘，得到的密文与PackVotes(votes)的加密结果
+// 携带同一明文。要求pubKey与各ciphertexts所在的Z_{n^2}一致，供VerifyPackedVote核对
+// 投票者提交的打包密文是否确由这组已验证合法的ciphertexts打包而来。
+func PackCiphertexts(pubKey *PublicKey, ciphertexts []*Ciphertext, bitsPerCandidate int) *Ciphertext {
+	base := new(big.Int).Lsh(one, uint(bitsPerCandidate))
+	weight := big.NewInt(1)
+	c := big.NewInt(1)
+	for _, ct := range ciphertexts {
+		c.Mul(c, new(big.Int).Exp(ct.C, weight, pubKey.NSquared))
+		c.Mod(c, pubKey.NSquared)
+		weight.Mul(weight, base)
+	}
+	return &Ciphertext{C: c, Len: byteLen(pubKey.NSquared)}
+}
+
+// VerifyPackedVote 校验packed确系由ciphertexts按bitsPerCandidate打包而来：重新计算
+// PackCiphertexts(ciphertexts)，与packed相除后应得到一个加密0的密文，并以proof（由
+// provePackedVote生成）验证这一点。只有ciphertexts已先通过VerifyBallot时，这一校验
+// 才能保证packed携带的也是合法选票——否则投票者仍可用一份合法ciphertexts搭配一个
+// 任意伪造的packed蒙混过关。
+func VerifyPackedVote(pubKey *PublicKey, ciphertexts []*Ciphertext, packed *Ciphertext, bitsPerCandidate int, proof *SumProof) bool {
+	expected := PackCiphertexts(pubKey, ciphertexts, bitsPerCandidate)
+	expectedInv := new(big.Int).ModInverse(expected.C, pubKey.NSquared)
+	if expectedInv == nil {
+		return false
+	}
+	diff := new(big.Int).Mod(new(big.Int).Mul(packed.C, expectedInv), pubKey.NSquared)
+	return verifySum(pubKey, diff, proof)
+}
+
+// UnpackVotes 是PackVotes的逆操作，按bitsPerCandidate位宽从m中逐槽还原出k位候选人的票数。
+func UnpackVotes(m *big.Int, k, bitsPerCandidate int) []int {
+	base := new(big.Int).Lsh(one, uint(bitsPerCandidate))
+	rem := new(big.Int).Set(m)
+	votes := make([]int, k)
+	for i := 0; i < k; i++ {
+		slot := new(big.Int).Mod(rem, base)
+		votes[i] = int(slot.Int64())
+		rem.Div(rem, base)
+	}
+	return votes
+}
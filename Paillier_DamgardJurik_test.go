@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// testDJKey 生成一把用于测试的小位数Damgård–Jurik密钥，仅用于加速测试，不代表生产环境的安全参数。
+func testDJKey(t *testing.T, s int) *DJPrivateKey {
+	t.Helper()
+	priv, err := GenerateKeyDJ(rand.Reader, 256, s)
+	if err != nil {
+		t.Fatalf("GenerateKeyDJ失败: %v", err)
+	}
+	return priv
+}
+
+func TestEncryptDecryptDJ_RoundTrips(t *testing.T) {
+	priv := testDJKey(t, 3)
+	plaintext := big.NewInt(123456789)
+	cipher, _, err := EncryptDJ(&priv.DJPublicKey, plaintext.Bytes())
+	if err != nil {
+		t.Fatalf("EncryptDJ失败: %v", err)
+	}
+
+	m, err := DecryptDJ(priv, cipher)
+	if err != nil {
+		t.Fatalf("DecryptDJ失败: %v", err)
+	}
+	if new(big.Int).SetBytes(m).Cmp(plaintext) != 0 {
+		t.Fatalf("解密结果不符：期望%v，实际%v", plaintext, new(big.Int).SetBytes(m))
+	}
+}
+
+func TestAddCipherDJ_HomomorphicallyAddsPlaintexts(t *testing.T) {
+	priv := testDJKey(t, 2)
+	c1, _, err := EncryptDJ(&priv.DJPublicKey, big.NewInt(10).Bytes())
+	if err != nil {
+		t.Fatalf("EncryptDJ失败: %v", err)
+	}
+	c2, _, err := EncryptDJ(&priv.DJPublicKey, big.NewInt(32).Bytes())
+	if err != nil {
+		t.Fatalf("EncryptDJ失败: %v", err)
+	}
+
+	sum := AddCipherDJ(&priv.DJPublicKey, c1, c2)
+	m, err := DecryptDJ(priv, sum)
+	if err != nil {
+		t.Fatalf("DecryptDJ失败: %v", err)
+	}
+	if new(big.Int).SetBytes(m).Cmp(big.NewInt(42)) != 0 {
+		t.Fatalf("同态相加结果不符：期望42，实际%v", new(big.Int).SetBytes(m))
+	}
+}
+
+func TestPackUnpackVotes_RoundTrips(t *testing.T) {
+	votes := []int{0, 1, 0, 0}
+	m := PackVotes(votes, 16)
+	got := UnpackVotes(m, len(votes), 16)
+	for i, v := range votes {
+		if got[i] != v {
+			t.Fatalf("第%d位候选人的票数还原不符：期望%d，实际%d", i, v, got[i])
+		}
+	}
+}
+
+func TestVerifyPackedVote_AcceptsGenuinePacking(t *testing.T) {
+	priv := testPaillierKey(t)
+	djPub := &DJPublicKey{N: priv.N, S: 1, NS: priv.N, NS1: priv.NSquared}
+	voter := NewVoter(&priv.PublicKey, 3, djPub, 16)
+
+	ballot, err := voter.PrepareBallot([]int{0, 1, 0})
+	if err != nil {
+		t.Fatalf("PrepareBallot失败: %v", err)
+	}
+	ciphertexts, _, packed, packedProof := voter.Cast(ballot)
+
+	if !VerifyPackedVote(&priv.PublicKey, ciphertexts, packed, 16, packedProof) {
+		t.Fatal("与Ciphertexts一致的打包密文本应通过VerifyPackedVote校验")
+	}
+}
+
+func TestVerifyPackedVote_RejectsMismatchedPacking(t *testing.T) {
+	priv := testPaillierKey(t)
+	djPub := &DJPublicKey{N: priv.N, S: 1, NS: priv.N, NS1: priv.NSquared}
+	voter := NewVoter(&priv.PublicKey, 3, djPub, 16)
+
+	ballot, err := voter.PrepareBallot([]int{0, 1, 0})
+	if err != nil {
+		t.Fatalf("PrepareBallot失败: %v", err)
+	}
+	ciphertexts, _, _, packedProof := voter.Cast(ballot)
+
+	// 恶意投票者在不更新证明的情况下，替换为一份携带不同votes的打包密文。
+	forgedVote := PackVotes([]int{1, 0, 0}, 16)
+	forged, _, err := EncryptDJ(djPub, forgedVote.Bytes())
+	if err != nil {
+		t.Fatalf("EncryptDJ失败: %v", err)
+	}
+
+	if VerifyPackedVote(&priv.PublicKey, ciphertexts, forged, 16, packedProof) {
+		t.Fatal("与Ciphertexts不一致的打包密文本应被VerifyPackedVote拒绝")
+	}
+}
@@ -1,166 +1,78 @@
 package main
 
-
 import (
 	"crypto/rand"
-	"errors"
 	"fmt"
-	"io"
 	"math/big"
 )
 
-var one = big.NewInt(1)
-
-// ErrMessageTooLong 当所需加密信息长度大于公钥长度时，报错。
-var ErrMessageTooLong = errors.New("信息过长！请调整公钥长度！")
-
-// GenerateKey 生成指定位数的公私钥。
-func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
-	// 生成素数p
-	var p *big.Int
-	var errChan = make(chan error, 1)
-	go func() {
-		var err error
-		p, err = rand.Prime(random, bits/2)
-		errChan <- err
-	}()
-
-	// 生成素数q
-	q, err := rand.Prime(random, bits/2)
-	if err != nil {
-		return nil, err
-	}
-
-	// 等待素数p生成完成
-	if err := <-errChan; err != nil {
-		return nil, err
-	}
-	n := new(big.Int).Mul(p, q)
-	pp := new(big.Int).Mul(p, p)
-	qq := new(big.Int).Mul(q, q)
-
-	return &PrivateKey{
-		PublicKey: PublicKey{
-			N:        n,
-			NSquared: new(big.Int).Mul(n, n),
-			G:        new(big.Int).Add(n, one), // g = n + 1
-		},
-		p:         p,
-		pp:        pp,
-		pminusone: new(big.Int).Sub(p, one),
-		q:         q,
-		qq:        qq,
-		qminusone: new(big.Int).Sub(q, one),
-	}, nil
-
-}
-
-// PrivateKey 私钥
-type PrivateKey struct {
-	PublicKey
-	p         *big.Int
-	pp        *big.Int
-	pminusone *big.Int
-	q         *big.Int
-	qq        *big.Int
-	qminusone *big.Int
-	Lambda    *big.Int
-}
-
-// PublicKey 公钥
-type PublicKey struct {
-	N        *big.Int
-	G        *big.Int
-	NSquared *big.Int
-}
-
-// L L(x)=(x-1)/n
-func L(x, n *big.Int) *big.Int {
-	return new(big.Int).Div(new(big.Int).Sub(x, one), n)
-}
-
-// Encrypt 加密。
-func Encrypt(pubKey *PublicKey, plainText []byte) ([]byte, *big.Int, error) {
-	r, err := rand.Int(rand.Reader, pubKey.N)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	m := new(big.Int).SetBytes(plainText)
-	if pubKey.N.Cmp(m) < 1 { // N < m
-		return nil, nil, ErrMessageTooLong
-	}
-
-	// c = g^m * r^n mod n^2 = [(m*n+1) mod n^2] * r^n mod n^2
-	c := new(big.Int).Mod(
-		new(big.Int).Mul(
-			new(big.Int).Mod(new(big.Int).Add(one, new(big.Int).Mul(m, pubKey.N)), pubKey.NSquared),
-			new(big.Int).Exp(r, pubKey.N, pubKey.NSquared),
-		),
-		pubKey.NSquared,
-	)
-
-	return c.Bytes(), r, nil
-}
-
-// Decrypt 解密。
-func Decrypt(privKey *PrivateKey, cipherText []byte) ([]byte, error) {
-	c := new(big.Int).SetBytes(cipherText)
-	if privKey.NSquared.Cmp(c) < 1 {
-		return nil, ErrMessageTooLong
-	}
-	mu := new(big.Int).ModInverse(privKey.Lambda, privKey.N)
-	m := new(big.Int).Mod(new(big.Int).Mul(L(new(big.Int).Exp(c, privKey.Lambda, privKey.NSquared), privKey.N), mu), privKey.N)
-	return m.Bytes(), nil
-}
-
-// AddCipher 将两个密文相乘，以达到明文相加的目的。
-func AddCipher(pubKey *PublicKey, cipher1, cipher2 []byte) []byte {
-	x := new(big.Int).SetBytes(cipher1)
-	y := new(big.Int).SetBytes(cipher2)
-	// x * y mod n^2
-	return new(big.Int).Mod(new(big.Int).Mul(x, y), pubKey.NSquared).Bytes()
-}
-
-func SendtoTeller(evts *[][]byte, evt [][]byte, canum int, pubKey *PublicKey) {
+func SendtoTeller(evts *[]*Ciphertext, evt []*Ciphertext, canum int, pubKey *PublicKey) {
 	for i := 0; i < canum; i++ {
 		(*evts)[i] = AddCipher(pubKey, (*evts)[i], evt[i])
 	}
 }
 
-func SendtoSpokesman(evts *[][]byte, canum int, privKey *PrivateKey) {
-	var err error
+// SendtoTellerPacked 将某位投票者打包好的单一密文计入总票数：与SendtoTeller逐候选人相加k次
+// 不同，这里每位投票者只需计票人做1次AddCipherDJ同态相加，即可把k位候选人的票数一并计入。
+func SendtoTellerPacked(tally *Ciphertext, packedVote *Ciphertext, pub *DJPublicKey) *Ciphertext {
+	return AddCipherDJ(pub, tally, packedVote)
+}
+
+// SendtoSpokesman 按(t, n)门限方案分布式完成计票结果的解密：取前pub.T位公布人各自提供一份
+// 部分解密及其诚实性的零知识证明，由CombineShares校验并合并出明文，而不必由单一公布人独自
+// 持有能解密全部选票的λ。
+func SendtoSpokesman(evts *[]*Ciphertext, canum int, pub *ThresholdPublicKey, shares []*KeyShare) {
+	results := make([][]byte, canum)
 	var Winner = 0
 	for i := 0; i < canum; i++ {
-		(*evts)[i], err = Decrypt(privKey, (*evts)[i])
+		m, err := thresholdDecryptOne(pub, shares, (*evts)[i])
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
-		fmt.Println("第", i+1, "位候选人获得了", new(big.Int).SetBytes((*evts)[i]), "张选票；")
-		if new(big.Int).SetBytes((*evts)[Winner]).Cmp(new(big.Int).SetBytes((*evts)[i])) < 1 {
+		results[i] = m
+		fmt.Println("第", i+1, "位候选人获得了", new(big.Int).SetBytes(results[i]), "张选票；")
+		if new(big.Int).SetBytes(results[Winner]).Cmp(new(big.Int).SetBytes(results[i])) < 1 {
 			Winner = i
 		}
 	}
-	fmt.Println("最终第", Winner+1, "位候选人获得的选票最多，为", new(big.Int).SetBytes((*evts)[Winner]), "张")
+	fmt.Println("最终第", Winner+1, "位候选人获得的选票最多，为", new(big.Int).SetBytes(results[Winner]), "张")
 	return
 }
 
+// bitsPerCandidate是PackVotes为每位候选人分配的槽位宽度：留出足够的进位空间，
+// 使VotersNum张选票逐一累加后每个槽位都不会溢出到相邻候选人的槽位。
+const bitsPerCandidate = 16
+
 func main() {
-	// 生成一个4096位私钥
-	privKey, err := GenerateKey(rand.Reader, 4096)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	privKey.Lambda = new(big.Int).Mul(privKey.pminusone, privKey.qminusone)
 	// 程序开始运行提示
 	fmt.Println("**********此程序模拟了基于Paillier算法的匿名电子投票的流程**********")
 	fmt.Println("首先每位投票者为候选人投票并将结果加密发送给计票人。每人只有1张选票，\n选票上被投票的候选者得到1张选票，其他候选者得到0张选票；")
 	fmt.Println("然后计票人将所有选票上对应候选人的加密的投票结果相乘，并将加密的统计\n结果发送给公布人；")
-	fmt.Println("最后公布人对统计的票数进行解密并公布。")
+	fmt.Println("最后公布人以(t, n)门限方案分布式解密并公布票数，任何不足t人的公布人子集都无法单独解密。")
+	fmt.Println("同时，每位投票者还会把自己的选票按候选人打包为单个整数后以Damgård–Jurik方案\n只加密1次，计票人对这份打包密文同态相加即可完成计票，而不必像上面那样逐候选人相加。")
 	fmt.Println("********************************************************************")
 
+	fmt.Print("请设置公布人总数：")
+	var Parties int
+	fmt.Scan(&Parties)
+	fmt.Print("请设置门限值（至少需要多少位公布人参与才能解密）：")
+	var Threshold int
+	fmt.Scan(&Threshold)
+	// 安全素数的生成比普通素数慢得多，这里用1024位演示门限方案
+	privKey, err := GenerateThresholdKey(rand.Reader, 1024, Threshold, Parties)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// 打包密文用于一次性计入某位投票者的全部votes，为使其能与逐候选人加密的密文
+	// 核对一致（见PackCiphertexts/VerifyPackedVote），必须与门限密钥共享同一个N，
+	// 退化为S=1的Damgård–Jurik密钥，使二者落在同一个Z_{N^2}群中；其明文空间NS=N、
+	// 密文群NS1=N^2恰与经典Paillier公钥一致。打包后的总票数因而也只能用(t, n)门限
+	// 方案解密（见下方的thresholdDecryptOne调用），不再单独生成、持有一把能独立
+	// 解密的DJ私钥，否则重新引入一个能单独解密全部选票的单点。
+	djPub := &DJPublicKey{N: privKey.N, S: 1, NS: privKey.N, NS1: privKey.NSquared}
+
 	fmt.Print("请设置候选者人数：")
 	var CandidatesNum int
 	fmt.Scan(&CandidatesNum)
@@ -168,10 +80,15 @@ func main() {
 		fmt.Println("候选者人数至少为1")
 		return
 	}
-	EncryptedVotes := make([][]byte, CandidatesNum)
+	EncryptedVotes := make([]*Ciphertext, CandidatesNum)
 	for i := 0; i < CandidatesNum; i++ {
 		EncryptedVotes[i], _, err = Encrypt(&privKey.PublicKey, big.NewInt(int64(0)).Bytes())
 	}
+	PackedTally, _, err := EncryptDJ(djPub, big.NewInt(0).Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Print("请设置投票者人数：")
 	var VotersNum int
 	fmt.Scan(&VotersNum)
@@ -196,25 +113,65 @@ func main() {
 				flag = true
 			}
 		}
-		// 将加密的投票结果发给计票人
-		EncryptedVote := make([][]byte, CandidatesNum)
-		for i := 0; i < CandidatesNum; i++ {
-			EncryptedVote[i], _, err = Encrypt(&privKey.PublicKey, big.NewInt(int64(Vote[i])).Bytes())
+
+		voter := NewVoter(&privKey.PublicKey, CandidatesNum, djPub, bitsPerCandidate)
+
+		// 在正式提交前，投票者可反复对同一意图发起挑战（审计后作废重制），直至选择提交为止，
+		// 以cast-as-intended验证确保加密结果确实忠实反映了其选择。
+		var ballot *Ballot
+		for {
+			ballot, err = voter.PrepareBallot(Vote)
 			if err != nil {
 				fmt.Println(err)
 				return
 			}
+			fmt.Println("已生成本次选票的加密结果，是否审计(1)或提交(2)该选票：")
+			var choice int
+			fmt.Scan(&choice)
+			if choice != 1 {
+				break
+			}
+			randomizers := voter.Challenge(ballot)
+			if !Audit(&privKey.PublicKey, ballot, randomizers) {
+				fmt.Println("审计失败！加密结果与意图不符！")
+				return
+			}
+			fmt.Println("审计通过，该选票已作废，请重新生成一份新的选票")
+		}
+
+		EncryptedVote, BallotProof, PackedVote, PackedProof := voter.Cast(ballot)
+		fmt.Println("对该投票结果进行加密并附上合法性证明后发送给计票人，同时附上打包后的单一密文及其与上述密文一致的证明")
+
+		fmt.Println("计票人校验选票合法性")
+		if !VerifyBallot(&privKey.PublicKey, EncryptedVote, BallotProof) {
+			fmt.Println("选票未通过合法性校验，已拒绝计票！")
+			return
 		}
-		fmt.Println("对该投票结果进行加密并发送给计票人")
-		fmt.Println("计票人对此投票结果进行计票")
+		if !VerifyPackedVote(&privKey.PublicKey, EncryptedVote, PackedVote, bitsPerCandidate, PackedProof) {
+			fmt.Println("打包密文与逐候选人密文不一致，已拒绝计票！")
+			return
+		}
+		fmt.Println("校验通过，计票人对此投票结果进行计票")
 		SendtoTeller(&EncryptedVotes, EncryptedVote, CandidatesNum, &privKey.PublicKey)
+		PackedTally = SendtoTellerPacked(PackedTally, PackedVote, djPub)
 	}
 
 	fmt.Println("-----计票人计票完成并将加密后的投票结果发给公布人-----")
 	fmt.Println("加密后的投票结果为：")
 	for i := 0; i < CandidatesNum; i++ {
-		fmt.Println("第", i+1, "位候选人获得的选票票数的加密结果为", new(big.Int).SetBytes(EncryptedVotes[i]))
+		fmt.Println("第", i+1, "位候选人获得的选票票数的加密结果为", EncryptedVotes[i].C)
+	}
+	fmt.Println("-----前T位公布人各自提交部分解密及证明，合并后公布最终的投票结果-----")
+	SendtoSpokesman(&EncryptedVotes, CandidatesNum, &privKey.ThresholdPublicKey, privKey.Shares)
+
+	fmt.Println("-----以打包密文核对计票结果（全程每位投票者只产生过1份计票用密文）-----")
+	packedResult, err := thresholdDecryptOne(&privKey.ThresholdPublicKey, privKey.Shares, PackedTally)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	packedVotes := UnpackVotes(new(big.Int).SetBytes(packedResult), CandidatesNum, bitsPerCandidate)
+	for i, count := range packedVotes {
+		fmt.Println("第", i+1, "位候选人获得了", count, "张选票；")
 	}
-	fmt.Println("-----公布人解密计票结果并公布最终的投票结果-----")
-	SendtoSpokesman(&EncryptedVotes, CandidatesNum, privKey)
 }
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// ErrNotEnoughShares 当参与合并的份额数量不足门限值时，报错。
+var ErrNotEnoughShares = errors.New("份额数量不足！无法恢复明文！")
+
+// ErrInvalidShareProof 当某一份额的零知识证明校验失败时，报错。
+var ErrInvalidShareProof = errors.New("该份额的零知识证明校验未通过！")
+
+// ThresholdPublicKey (t, n) 门限方案下各方共用的公开参数。
+type ThresholdPublicKey struct {
+	PublicKey
+	T       int        // 门限值，至少需要T份才能恢复明文
+	Parties int        // 参与方总数
+	Delta   *big.Int   // Delta = Parties!
+	V       *big.Int   // 验证密钥，Z_{n^2}*上的随机二次剩余
+	Vi      []*big.Int // 各参与方的验证密钥，Vi[i] = V^(Delta*share_i)
+}
+
+// KeyShare 单个参与方持有的解密份额，share_i = f(i)，其中f为门限生成时构造的多项式。
+type KeyShare struct {
+	Index int      // 参与方编号，从1开始
+	Value *big.Int // f(Index)
+}
+
+// ThresholdPrivateKey 门限解密生成结果：公开参数加全部份额（便于演示，真实场景中份额应分发给各方后销毁此处的汇总）。
+type ThresholdPrivateKey struct {
+	ThresholdPublicKey
+	Shares []*KeyShare
+}
+
+// ZKProof 证明log_{v^Delta}(v_i) = log_{c^{4Delta}}(c_i^2)（二者均为share_i）的等离散对数Schnorr式证明，
+// 用于证明某一解密份额确系按声明的verification key诚实计算得出。
+type ZKProof struct {
+	A1 *big.Int // (v^Delta)^r mod N^2
+	A2 *big.Int // (c^(4Delta))^r mod N^2
+	E  *big.Int // 挑战 e = H(v^Delta, v_i, base2, result2, A1, A2)
+	Z  *big.Int // 响应 z = r + e*share_i （整数上，不做模约化）
+}
+
+// factorial 计算n!。
+func factorial(n int) *big.Int {
+	result := big.NewInt(1)
+	for i := 2; i <= n; i++ {
+		result.Mul(result, big.NewInt(int64(i)))
+	}
+	return result
+}
+
+// crt 中国剩余定理：求解 x ≡ a1 (mod m1)，x ≡ a2 (mod m2)，其中m1与m2互素。
+func crt(a1, m1, a2, m2 *big.Int) *big.Int {
+	m1Inv := new(big.Int).ModInverse(m1, m2)
+	diff := new(big.Int).Sub(a2, a1)
+	diff.Mod(diff, m2)
+	k := new(big.Int).Mul(diff, m1Inv)
+	k.Mod(k, m2)
+	x := new(big.Int).Mul(k, m1)
+	x.Add(x, a1)
+	return x
+}
+
+// safePrime 生成一个bits位的安全素数p=2p'+1，同时返回p'。
+func safePrime(random io.Reader, bits int) (p, pPrime *big.Int, err error) {
+	for {
+		pPrime, err = rand.Prime(random, bits-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		p = new(big.Int).Lsh(pPrime, 1)
+		p.Add(p, one)
+		if p.ProbablyPrime(20) {
+			return p, pPrime, nil
+		}
+	}
+}
+
+// GenerateThresholdKey 生成(t, n)门限Paillier密钥：n个参与方各持一份解密份额，
+// 任意t份可联合恢复明文，而不必重建完整的私钥λ。
+//
+// 做法：取安全素数p=2p'+1、q=2q'+1，m=p'q'，需要分享的秘密d满足
+// d≡0 (mod m)、d≡1 (mod n)（通过CRT求得）；随机生成Z_{n·m}上的t-1次多项式
+// f(X)=d+a_1X+...+a_{t-1}X^{t-1}，share_i=f(i)。
+func GenerateThresholdKey(random io.Reader, bits, t, parties int) (*ThresholdPrivateKey, error) {
+	if t <= 0 || t > parties {
+		return nil, errors.New("门限值t必须满足0 < t <= parties")
+	}
+
+	p, pPrime, err := safePrime(random, bits/2)
+	if err != nil {
+		return nil, err
+	}
+	q, qPrime, err := safePrime(random, bits/2)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(big.Int).Mul(p, q)
+	nSquared := new(big.Int).Mul(n, n)
+	m := new(big.Int).Mul(pPrime, qPrime)
+
+	d := crt(big.NewInt(0), m, one, n)
+	nm := new(big.Int).Mul(n, m)
+	d.Mod(d, nm)
+
+	// 随机生成多项式系数a_1...a_{t-1}，构造f(X)=d+a_1X+...+a_{t-1}X^{t-1}
+	coeffs := make([]*big.Int, t)
+	coeffs[0] = d
+	for i := 1; i < t; i++ {
+		a, err := rand.Int(random, nm)
+		if err != nil {
+			return nil, err
+		}
+		coeffs[i] = a
+	}
+
+	shares := make([]*KeyShare, parties)
+	for i := 1; i <= parties; i++ {
+		shares[i-1] = &KeyShare{Index: i, Value: evalPoly(coeffs, big.NewInt(int64(i)), nm)}
+	}
+
+	delta := factorial(parties)
+
+	// v为Z_{n^2}*上的随机二次剩余，作为份额验证的公共基。
+	x, err := rand.Int(random, nSquared)
+	if err != nil {
+		return nil, err
+	}
+	v := new(big.Int).Exp(x, big.NewInt(2), nSquared)
+
+	// λ(n^2) = n*λ(n)，对安全素数p=2p'+1、q=2q'+1而言λ(n)=lcm(p-1,q-1)=2p'q'=2m，
+	// 故λ(n^2)=2nm；v是Z_{n^2}*上的二次剩余，其阶整除λ(n^2)，可借此对share.Value做盲化。
+	order := new(big.Int).Mul(big.NewInt(2), new(big.Int).Mul(n, m))
+	vi := make([]*big.Int, parties)
+	for i := 0; i < parties; i++ {
+		exp := new(big.Int).Mul(delta, shares[i].Value)
+		viVal, err := expBlinded(v, exp, order, nSquared)
+		if err != nil {
+			return nil, err
+		}
+		vi[i] = viVal
+	}
+
+	pub := ThresholdPublicKey{
+		PublicKey: PublicKey{
+			N:        n,
+			NSquared: nSquared,
+			G:        new(big.Int).Add(n, one),
+		},
+		T:       t,
+		Parties: parties,
+		Delta:   delta,
+		V:       v,
+		Vi:      vi,
+	}
+
+	return &ThresholdPrivateKey{ThresholdPublicKey: pub, Shares: shares}, nil
+}
+
+// evalPoly 计算多项式f在x处的取值，系数低次在前，结果模mod。
+func evalPoly(coeffs []*big.Int, x, mod *big.Int) *big.Int {
+	result := new(big.Int).Set(coeffs[len(coeffs)-1])
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, coeffs[i])
+		result.Mod(result, mod)
+	}
+	return result
+}
+
+// hashZKChallenge 按Fiat-Shamir变换计算等离散对数证明的挑战e。
+func hashZKChallenge(values ...*big.Int) *big.Int {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write(v.Bytes())
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// PartialDecrypt 使用一份解密份额对密文做部分解密，并附带证明该份额诚实参与计算的ZK证明。
+func PartialDecrypt(pub *ThresholdPublicKey, share *KeyShare, cipher *Ciphertext) ([]byte, *ZKProof, error) {
+	c := cipher.C
+	if pub.NSquared.Cmp(c) < 1 {
+		return nil, nil, ErrMessageTooLong
+	}
+
+	exp := new(big.Int).Mul(big.NewInt(2), pub.Delta)
+	exp.Mul(exp, share.Value)
+	// 持有单份额的一方并不掌握N的分解，无法像expBlinded那样给出Z_{N^2}*的真实阶
+	// （给出该阶等价于给出N的分解），故改用expBlindedUnknownOrder盲化该处的secret-dependent模幂。
+	ci, err := expBlindedUnknownOrder(c, exp, pub.NSquared)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// base2 = c^(4*Delta) mod N^2, result2 = ci^2 mod N^2
+	base2Exp := new(big.Int).Mul(big.NewInt(4), pub.Delta)
+	base2 := new(big.Int).Exp(c, base2Exp, pub.NSquared)
+	result2 := new(big.Int).Exp(ci, big.NewInt(2), pub.NSquared)
+
+	// Schnorr式等离散对数证明：vi=V^(Delta*share_i)=base1^share_i，result2=base2^share_i，
+	// 证明log_base1(vi)=log_base2(result2)=share.Value
+	base1 := new(big.Int).Exp(pub.V, pub.Delta, pub.NSquared)
+	vi := pub.Vi[share.Index-1]
+	bound := new(big.Int).Lsh(pub.N, uint(pub.N.BitLen()+128))
+	r, err := rand.Int(rand.Reader, bound)
+	if err != nil {
+		return nil, nil, err
+	}
+	a1 := new(big.Int).Exp(base1, r, pub.NSquared)
+	a2 := new(big.Int).Exp(base2, r, pub.NSquared)
+	e := hashZKChallenge(base1, vi, base2, result2, a1, a2)
+	z := new(big.Int).Mul(e, share.Value)
+	z.Add(z, r)
+
+	return ci.Bytes(), &ZKProof{A1: a1, A2: a2, E: e, Z: z}, nil
+}
+
+// verifyPartialDecryptProof 校验PartialDecrypt产生的ZK证明，确认ci确实是按vi对应的份额诚实计算得出。
+func verifyPartialDecryptProof(pub *ThresholdPublicKey, index int, cipher *Ciphertext, partial []byte, proof *ZKProof) bool {
+	c := cipher.C
+	ci := new(big.Int).SetBytes(partial)
+
+	base1 := new(big.Int).Exp(pub.V, pub.Delta, pub.NSquared)
+	base2Exp := new(big.Int).Mul(big.NewInt(4), pub.Delta)
+	base2 := new(big.Int).Exp(c, base2Exp, pub.NSquared)
+	result2 := new(big.Int).Exp(ci, big.NewInt(2), pub.NSquared)
+	vi := pub.Vi[index-1]
+
+	e := hashZKChallenge(base1, vi, base2, result2, proof.A1, proof.A2)
+	if e.Cmp(proof.E) != 0 {
+		return false
+	}
+
+	// base1^z =? A1 * vi^e
+	left1 := new(big.Int).Exp(base1, proof.Z, pub.NSquared)
+	right1 := new(big.Int).Exp(vi, e, pub.NSquared)
+	right1.Mul(right1, proof.A1)
+	right1.Mod(right1, pub.NSquared)
+	if left1.Cmp(right1) != 0 {
+		return false
+	}
+
+	// base2^z =? A2 * result2^e
+	left2 := new(big.Int).Exp(base2, proof.Z, pub.NSquared)
+	right2 := new(big.Int).Exp(result2, e, pub.NSquared)
+	right2.Mul(right2, proof.A2)
+	right2.Mod(right2, pub.NSquared)
+	return left2.Cmp(right2) == 0
+}
+
+// thresholdDecryptOne 取shares中前pub.T份份额对cipher做部分解密并合并，封装了
+// “逐份PartialDecrypt再CombineShares”这一套完整流程，供SendtoSpokesman等需要
+// 按(t, n)门限方案解密单一密文的场合复用，避免在多处重复该循环。
+func thresholdDecryptOne(pub *ThresholdPublicKey, shares []*KeyShare, cipher *Ciphertext) ([]byte, error) {
+	indices := make([]int, pub.T)
+	for i := 0; i < pub.T; i++ {
+		indices[i] = shares[i].Index
+	}
+
+	partials := make([][]byte, pub.T)
+	proofs := make([]*ZKProof, pub.T)
+	for j := 0; j < pub.T; j++ {
+		partial, proof, err := PartialDecrypt(pub, shares[j], cipher)
+		if err != nil {
+			return nil, err
+		}
+		partials[j] = partial
+		proofs[j] = proof
+	}
+
+	return CombineShares(pub, partials, proofs, cipher, indices)
+}
+
+// CombineShares 将至少T份部分解密结果合并还原明文，indices为各份额对应的参与方编号（从1开始）。
+func CombineShares(pub *ThresholdPublicKey, shares [][]byte, proofs []*ZKProof, cipher *Ciphertext, indices []int) ([]byte, error) {
+	if len(shares) < pub.T {
+		return nil, ErrNotEnoughShares
+	}
+	for i, idx := range indices {
+		if !verifyPartialDecryptProof(pub, idx, cipher, shares[i], proofs[i]) {
+			return nil, ErrInvalidShareProof
+		}
+	}
+
+	cPrime := big.NewInt(1)
+	for i, idx := range indices {
+		lambda := lagrangeCoefficient(pub.Delta, idx, indices)
+		ci := new(big.Int).SetBytes(shares[i])
+
+		exp := new(big.Int).Mul(big.NewInt(2), lambda.num)
+		term := new(big.Int).Exp(ci, exp, pub.NSquared)
+		if lambda.negative {
+			term.ModInverse(term, pub.NSquared)
+		}
+		cPrime.Mul(cPrime, term)
+		cPrime.Mod(cPrime, pub.NSquared)
+	}
+
+	mOverN := L(cPrime, pub.N)
+	fourDeltaSquared := new(big.Int).Mul(big.NewInt(4), new(big.Int).Mul(pub.Delta, pub.Delta))
+	inv := new(big.Int).ModInverse(fourDeltaSquared, pub.N)
+	m := new(big.Int).Mul(mOverN, inv)
+	m.Mod(m, pub.N)
+	return m.Bytes(), nil
+}
+
+// signedInt 保存一个带符号的大整数，num为其绝对值。
+type signedInt struct {
+	num      *big.Int
+	negative bool
+}
+
+// lagrangeCoefficient 计算参与方i在集合indices上的拉格朗日系数 λ_{0,i}^S = Delta * Π_{j≠i} -j/(i-j)。
+// 由于Delta=parties!，该系数恒为整数，这里以(绝对值, 符号)的形式返回以避免处理有理数。
+func lagrangeCoefficient(delta *big.Int, i int, indices []int) signedInt {
+	num := new(big.Int).Set(delta)
+	den := big.NewInt(1)
+	negative := false
+	for _, j := range indices {
+		if j == i {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(-j)))
+		den.Mul(den, big.NewInt(int64(i-j)))
+	}
+	if num.Sign() < 0 {
+		negative = !negative
+		num.Neg(num)
+	}
+	if den.Sign() < 0 {
+		negative = !negative
+		den.Neg(den)
+	}
+	num.Div(num, den)
+	return signedInt{num: num, negative: negative}
+}
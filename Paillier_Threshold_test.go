@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// testThresholdKey 生成一把用于测试的小位数(t, n)门限密钥，仅用于加速测试，不代表生产环境的安全参数。
+func testThresholdKey(t *testing.T, bits, threshold, parties int) *ThresholdPrivateKey {
+	t.Helper()
+	priv, err := GenerateThresholdKey(rand.Reader, bits, threshold, parties)
+	if err != nil {
+		t.Fatalf("GenerateThresholdKey失败: %v", err)
+	}
+	return priv
+}
+
+func TestThresholdDecrypt_RoundTrips(t *testing.T) {
+	priv := testThresholdKey(t, 256, 2, 3)
+	plaintext := big.NewInt(7)
+	cipher, _, err := Encrypt(&priv.PublicKey, plaintext.Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+
+	m, err := thresholdDecryptOne(&priv.ThresholdPublicKey, priv.Shares, cipher)
+	if err != nil {
+		t.Fatalf("thresholdDecryptOne失败: %v", err)
+	}
+	if new(big.Int).SetBytes(m).Cmp(plaintext) != 0 {
+		t.Fatalf("解密结果不符：期望%v，实际%v", plaintext, new(big.Int).SetBytes(m))
+	}
+}
+
+func TestCombineShares_RejectsNotEnoughShares(t *testing.T) {
+	priv := testThresholdKey(t, 256, 3, 4)
+	cipher, _, err := Encrypt(&priv.PublicKey, big.NewInt(1).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+
+	// 只提供T-1份份额，不足门限值，应被CombineShares拒绝。
+	shares := priv.Shares[:priv.T-1]
+	indices := make([]int, len(shares))
+	partials := make([][]byte, len(shares))
+	proofs := make([]*ZKProof, len(shares))
+	for i, share := range shares {
+		partial, proof, err := PartialDecrypt(&priv.ThresholdPublicKey, share, cipher)
+		if err != nil {
+			t.Fatalf("PartialDecrypt失败: %v", err)
+		}
+		indices[i] = share.Index
+		partials[i] = partial
+		proofs[i] = proof
+	}
+
+	if _, err := CombineShares(&priv.ThresholdPublicKey, partials, proofs, cipher, indices); err != ErrNotEnoughShares {
+		t.Fatalf("份额数量不足时应返回ErrNotEnoughShares，实际返回: %v", err)
+	}
+}
+
+func TestCombineShares_RejectsForgedProof(t *testing.T) {
+	priv := testThresholdKey(t, 256, 2, 3)
+	cipher, _, err := Encrypt(&priv.PublicKey, big.NewInt(1).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+
+	shares := priv.Shares[:priv.T]
+	indices := make([]int, len(shares))
+	partials := make([][]byte, len(shares))
+	proofs := make([]*ZKProof, len(shares))
+	for i, share := range shares {
+		partial, proof, err := PartialDecrypt(&priv.ThresholdPublicKey, share, cipher)
+		if err != nil {
+			t.Fatalf("PartialDecrypt失败: %v", err)
+		}
+		indices[i] = share.Index
+		partials[i] = partial
+		proofs[i] = proof
+	}
+
+	// 篡改其中一份证明的响应值z，使其不再能通过等离散对数校验。
+	proofs[0] = &ZKProof{A1: proofs[0].A1, A2: proofs[0].A2, E: proofs[0].E, Z: new(big.Int).Add(proofs[0].Z, one)}
+
+	if _, err := CombineShares(&priv.ThresholdPublicKey, partials, proofs, cipher, indices); err != ErrInvalidShareProof {
+		t.Fatalf("伪造的份额证明本应被拒绝，实际返回: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import "math/big"
+
+// VerifyEncryption 重新计算(1+m·n)·r^n mod n^2并与ciphertext比较，
+// 用于在已知明文m与加密随机数r的前提下，确认ciphertext确系由Encrypt(m)产生。
+func VerifyEncryption(pubKey *PublicKey, plaintext, r *big.Int, ciphertext *Ciphertext) bool {
+	c := new(big.Int).Mod(
+		new(big.Int).Mul(
+			new(big.Int).Mod(new(big.Int).Add(one, new(big.Int).Mul(plaintext, pubKey.N)), pubKey.NSquared),
+			new(big.Int).Exp(r, pubKey.N, pubKey.NSquared),
+		),
+		pubKey.NSquared,
+	)
+	return c.Cmp(ciphertext.C) == 0
+}
+
+// Ballot 是PrepareBallot生成的一份尚未决定去向的选票：Ciphertexts与Proof可提交给计票人用于
+// 合法性校验，Packed是同一份votes另行打包加密出的单一密文，PackedProof证明Packed与Ciphertexts
+// 携带的确系同一组votes（否则计票人无从得知Packed未被恶意篡改），供计票人以1次同态运算计入总票数；
+// Plaintexts与Randomizers仅在Challenge时才会被公开，用于投票者本人（或外部审计设备）核对密文。
+type Ballot struct {
+	Plaintexts  []*big.Int
+	Ciphertexts []*Ciphertext
+	Randomizers []*big.Int
+	Proof       *BallotProof
+	Packed      *Ciphertext
+	PackedProof *SumProof
+}
+
+// Voter 代表一名持有候选人总数、投票公钥与打包计票用DJ公钥的投票者，可反复PrepareBallot，
+// 对每份选票在Cast提交与Challenge挑战之间二选一（Benaloh式的cast-or-challenge）。
+type Voter struct {
+	CandidatesNum    int
+	PubKey           *PublicKey
+	DJPubKey         *DJPublicKey
+	BitsPerCandidate int
+}
+
+// NewVoter 创建一名投票者。djPubKey与bitsPerCandidate用于PrepareBallot中PackVotes打包计票，
+// 要求candidatesNum*bitsPerCandidate不超过djPubKey明文空间的位数。
+func NewVoter(pubKey *PublicKey, candidatesNum int, djPubKey *DJPublicKey, bitsPerCandidate int) *Voter {
+	return &Voter{
+		CandidatesNum:    candidatesNum,
+		PubKey:           pubKey,
+		DJPubKey:         djPubKey,
+		BitsPerCandidate: bitsPerCandidate,
+	}
+}
+
+// PrepareBallot 按votes（每位候选人得票0或1，且恰好一项为1）生成一份选票：除逐项加密明文并
+// 附上证明选票合法性的零知识证明外，还将votes按PackVotes打包为单个大整数并以DJPubKey加密一次，
+// 使计票人之后只需对该Packed密文做1次同态相加即可完成计票，而不必对k份Ciphertexts逐一相加。
+// Packed是投票者独立生成的密文，为防止其与Ciphertexts实际携带的votes不一致（计票人单凭Packed
+// 本身无从分辨），额外生成PackedProof：证明Packed与PackCiphertexts(Ciphertexts)这一由计票人可
+// 自行复核的组合密文携带同一明文，从而把Packed绑定到已通过ProveBallot证明的那组votes上。
+func (v *Voter) PrepareBallot(votes []int) (*Ballot, error) {
+	plaintexts := make([]*big.Int, v.CandidatesNum)
+	ciphertexts := make([]*Ciphertext, v.CandidatesNum)
+	randomizers := make([]*big.Int, v.CandidatesNum)
+	for i, vote := range votes {
+		plaintexts[i] = big.NewInt(int64(vote))
+		ct, r, err := Encrypt(v.PubKey, plaintexts[i].Bytes())
+		if err != nil {
+			return nil, err
+		}
+		ciphertexts[i] = ct
+		randomizers[i] = r
+	}
+
+	proof, err := ProveBallot(v.PubKey, votes, randomizers)
+	if err != nil {
+		return nil, err
+	}
+
+	packedVote := PackVotes(votes, v.BitsPerCandidate)
+	packed, packedR, err := EncryptDJ(v.DJPubKey, packedVote.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	packedProof, err := provePackedVote(v.PubKey, ciphertexts, randomizers, v.BitsPerCandidate, packed, packedR)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ballot{
+		Plaintexts:  plaintexts,
+		Ciphertexts: ciphertexts,
+		Randomizers: randomizers,
+		Proof:       proof,
+		Packed:      packed,
+		PackedProof: packedProof,
+	}, nil
+}
+
+// Cast 将选票正式提交给计票人：返回供合法性校验的密文及其证明、供计票累加的单一打包密文，
+// 以及证明该打包密文与前述密文一致的PackedProof，调用方应随后丢弃ballot中的随机数，
+// 不再允许对同一份选票发起Challenge。
+func (v *Voter) Cast(ballot *Ballot) ([]*Ciphertext, *BallotProof, *Ciphertext, *SumProof) {
+	return ballot.Ciphertexts, ballot.Proof, ballot.Packed, ballot.PackedProof
+}
+
+// Challenge 对尚未提交的选票发起Benaloh式挑战：公开其加密随机数，供Audit重新计算密文，
+// 随后该选票即作废，投票者须用PrepareBallot重新生成一份新选票再行Cast或Challenge。
+func (v *Voter) Challenge(ballot *Ballot) []*big.Int {
+	return ballot.Randomizers
+}
+
+// Audit 使用Challenge公开的随机数重新计算每一项密文，确认其与PrepareBallot时的意图明文一致。
+func Audit(pubKey *PublicKey, ballot *Ballot, randomizers []*big.Int) bool {
+	for i, ct := range ballot.Ciphertexts {
+		if !VerifyEncryption(pubKey, ballot.Plaintexts[i], randomizers[i], ct) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+// testVoter 创建一名用于测试的投票者，与其配套的经典Paillier公钥共用同一个N的DJ公钥。
+func testVoter(t *testing.T, candidatesNum int) (*Voter, *PrivateKey) {
+	t.Helper()
+	priv := testPaillierKey(t)
+	djPub := &DJPublicKey{N: priv.N, S: 1, NS: priv.N, NS1: priv.NSquared}
+	return NewVoter(&priv.PublicKey, candidatesNum, djPub, 16), priv
+}
+
+func TestVoterCast_ValidBallotRoundTrips(t *testing.T) {
+	voter, priv := testVoter(t, 3)
+	ballot, err := voter.PrepareBallot([]int{0, 0, 1})
+	if err != nil {
+		t.Fatalf("PrepareBallot失败: %v", err)
+	}
+
+	ciphertexts, proof, packed, packedProof := voter.Cast(ballot)
+	if !VerifyBallot(&priv.PublicKey, ciphertexts, proof) {
+		t.Fatal("合法选票未能通过VerifyBallot校验")
+	}
+	if !VerifyPackedVote(&priv.PublicKey, ciphertexts, packed, 16, packedProof) {
+		t.Fatal("合法选票的打包密文未能通过VerifyPackedVote校验")
+	}
+}
+
+func TestVoterChallengeAudit_DetectsMismatch(t *testing.T) {
+	voter, priv := testVoter(t, 2)
+	ballot, err := voter.PrepareBallot([]int{1, 0})
+	if err != nil {
+		t.Fatalf("PrepareBallot失败: %v", err)
+	}
+
+	randomizers := voter.Challenge(ballot)
+	if !Audit(&priv.PublicKey, ballot, randomizers) {
+		t.Fatal("未被篡改的选票本应通过Audit审计")
+	}
+
+	// 篡改选票中第一项密文，使其不再与Plaintexts、Randomizers对应。
+	tampered := *ballot
+	forged, _, err := Encrypt(&priv.PublicKey, big.NewInt(0).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+	tamperedCiphertexts := append([]*Ciphertext(nil), ballot.Ciphertexts...)
+	tamperedCiphertexts[0] = forged
+	tampered.Ciphertexts = tamperedCiphertexts
+
+	if Audit(&priv.PublicKey, &tampered, randomizers) {
+		t.Fatal("密文与意图不符的选票本应被Audit拒绝")
+	}
+}
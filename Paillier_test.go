@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// testPaillierKey 生成一把用于测试的小位数经典Paillier密钥，仅用于加速测试，不代表生产环境的安全参数。
+func testPaillierKey(t *testing.T) *PrivateKey {
+	t.Helper()
+	priv, err := GenerateKey(rand.Reader, 256)
+	if err != nil {
+		t.Fatalf("GenerateKey失败: %v", err)
+	}
+	return priv
+}
+
+func TestEncryptDecrypt_RoundTrips(t *testing.T) {
+	priv := testPaillierKey(t)
+	plaintext := big.NewInt(42)
+	cipher, _, err := Encrypt(&priv.PublicKey, plaintext.Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+
+	m, err := Decrypt(priv, cipher)
+	if err != nil {
+		t.Fatalf("Decrypt失败: %v", err)
+	}
+	if new(big.Int).SetBytes(m).Cmp(plaintext) != 0 {
+		t.Fatalf("解密结果不符：期望%v，实际%v", plaintext, new(big.Int).SetBytes(m))
+	}
+}
+
+func TestAddCipher_HomomorphicallyAddsPlaintexts(t *testing.T) {
+	priv := testPaillierKey(t)
+	c1, _, err := Encrypt(&priv.PublicKey, big.NewInt(3).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+	c2, _, err := Encrypt(&priv.PublicKey, big.NewInt(5).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt失败: %v", err)
+	}
+
+	sum := AddCipher(&priv.PublicKey, c1, c2)
+	m, err := Decrypt(priv, sum)
+	if err != nil {
+		t.Fatalf("Decrypt失败: %v", err)
+	}
+	if new(big.Int).SetBytes(m).Cmp(big.NewInt(8)) != 0 {
+		t.Fatalf("同态相加结果不符：期望8，实际%v", new(big.Int).SetBytes(m))
+	}
+}